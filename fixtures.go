@@ -0,0 +1,291 @@
+package dbro
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// FixtureOptions configures LoadFixtures.
+type FixtureOptions struct {
+	// Dir is the directory containing one fixture file per table, named
+	// "<table>.yml", "<table>.yaml" or "<table>.json".
+	Dir string
+}
+
+// fixtureRow is a named set of column values for a single row, keyed by a
+// fixture-local row name (e.g. "alice") so other rows can reference it.
+type fixtureRow = map[string]any
+
+// LoadFixtures reads one file per table from opts.Dir, deletes all rows from
+// those tables, and inserts the rows they define, all inside a single transaction.
+// Values may use "{{now}}" and "{{uuid}}" placeholders, and rows may
+// reference other rows with "{{table.rowName.column}}", resolved after all
+// fixture files have been loaded.
+func (m *ConnectionManager) LoadFixtures(name string, opts FixtureOptions) error {
+	return m.LoadFixturesFS(name, os.DirFS(opts.Dir), ".")
+}
+
+// LoadFixturesFS is like LoadFixtures but reads fixture files from dir within
+// fsys, e.g. one embedded with "//go:embed fixtures/*".
+func (m *ConnectionManager) LoadFixturesFS(name string, fsys fs.FS, dir string) error {
+	files, err := discoverFixtureFiles(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	data, err := loadFixtureFiles(fsys, files)
+	if err != nil {
+		return err
+	}
+
+	return m.insertFixtures(name, data)
+}
+
+// FixturesFromStructs loads fixtures into name directly from Go values
+// instead of files on disk. Each key in data is a table name; its rows may be
+// structs or maps, as accepted by gorm's Create.
+func (m *ConnectionManager) FixturesFromStructs(name string, data map[string][]any) error {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceTableRows(tx, config.DriverName, data)
+	})
+}
+
+func (m *ConnectionManager) insertFixtures(name string, data map[string]map[string]fixtureRow) error {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	rowsByTable := make(map[string][]any, len(data))
+	for table, rows := range data {
+		tableRows := make([]any, 0, len(rows))
+		for _, fields := range rows {
+			tableRows = append(tableRows, fields)
+		}
+		rowsByTable[table] = tableRows
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceTableRows(tx, config.DriverName, rowsByTable)
+	})
+}
+
+// replaceTableRows clears each table in data and inserts its rows, with
+// foreign key enforcement suspended for the duration of the operation. It is
+// shared by FixturesFromStructs and insertFixtures, which differ only in
+// where their row data comes from.
+func replaceTableRows(tx *gorm.DB, driver string, data map[string][]any) (err error) {
+	if err = disableForeignKeys(tx, driver); err != nil {
+		return err
+	}
+	defer func() {
+		if reErr := enableForeignKeys(tx, driver); err == nil {
+			err = reErr
+		}
+	}()
+
+	for table := range data {
+		if err = tx.Exec(fmt.Sprintf("DELETE FROM %s", table)).Error; err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", table, err)
+		}
+	}
+	for table, rows := range data {
+		for _, row := range rows {
+			if err = tx.Table(table).Create(row).Error; err != nil {
+				return fmt.Errorf("failed to insert fixture row into %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// disableForeignKeys issues the per-dialect statement used to suspend foreign
+// key enforcement for the duration of a transaction. SQLite only honors
+// "PRAGMA foreign_keys" outside of a transaction, so it uses
+// "defer_foreign_keys" instead, which is a no-op outside one but, within a
+// transaction, defers enforcement to COMMIT and resets itself automatically
+// once the transaction ends.
+func disableForeignKeys(tx *gorm.DB, driver string) error {
+	switch driver {
+	case DbSqlite, DbLibSQL:
+		return tx.Exec("PRAGMA defer_foreign_keys = ON").Error
+	case DbMySQL:
+		return tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error
+	case DbPostgres:
+		return tx.Exec("SET session_replication_role = 'replica'").Error
+	default:
+		return fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// enableForeignKeys restores foreign key enforcement disabled by
+// disableForeignKeys. SQLite/libSQL need no action: defer_foreign_keys resets
+// itself when the transaction commits.
+func enableForeignKeys(tx *gorm.DB, driver string) error {
+	switch driver {
+	case DbSqlite, DbLibSQL:
+		return nil
+	case DbMySQL:
+		return tx.Exec("SET FOREIGN_KEY_CHECKS = 1").Error
+	case DbPostgres:
+		return tx.Exec("SET session_replication_role = 'origin'").Error
+	default:
+		return fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// discoverFixtureFiles returns the fixture files in dir within fsys keyed by
+// table name, derived from each file's name without its extension.
+func discoverFixtureFiles(fsys fs.FS, dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures directory %s: %w", dir, err)
+	}
+
+	files := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch ext := path.Ext(entry.Name()); ext {
+		case ".yml", ".yaml", ".json":
+			table := strings.TrimSuffix(entry.Name(), ext)
+			files[table] = path.Join(dir, entry.Name())
+		}
+	}
+	return files, nil
+}
+
+// loadFixtureFiles parses each fixture file into its named rows and resolves
+// "{{now}}"/"{{uuid}}" placeholders, then cross-row "{{table.row.column}}"
+// references in a second pass.
+func loadFixtureFiles(fsys fs.FS, files map[string]string) (map[string]map[string]fixtureRow, error) {
+	data := make(map[string]map[string]fixtureRow, len(files))
+	for table, filePath := range files {
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture file %s: %w", filePath, err)
+		}
+
+		var rows map[string]fixtureRow
+		if strings.HasSuffix(filePath, ".json") {
+			err = json.Unmarshal(content, &rows)
+		} else {
+			err = yaml.Unmarshal(content, &rows)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixture file %s: %w", filePath, err)
+		}
+		data[table] = rows
+	}
+
+	expandLiteralPlaceholders(data)
+	if err := resolveFixtureReferences(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+var (
+	fixtureRefRe  = regexp.MustCompile(`^\{\{\s*([A-Za-z0-9_]+)\.([A-Za-z0-9_]+)\.([A-Za-z0-9_]+)\s*\}\}$`)
+	fixtureNowRe  = regexp.MustCompile(`\{\{\s*now\s*\}\}`)
+	fixtureUUIDRe = regexp.MustCompile(`\{\{\s*uuid\s*\}\}`)
+)
+
+// expandLiteralPlaceholders resolves "{{now}}" and "{{uuid}}" in every string
+// field, each occurrence of "{{uuid}}" getting its own generated value.
+func expandLiteralPlaceholders(data map[string]map[string]fixtureRow) {
+	now := time.Now().Format(time.RFC3339)
+	for _, rows := range data {
+		for _, fields := range rows {
+			for col, val := range fields {
+				str, ok := val.(string)
+				if !ok {
+					continue
+				}
+				str = fixtureNowRe.ReplaceAllString(str, now)
+				str = fixtureUUIDRe.ReplaceAllStringFunc(str, func(string) string { return newUUIDv4() })
+				fields[col] = str
+			}
+		}
+	}
+}
+
+// resolveFixtureReferences replaces fields that are exactly "{{table.row.column}}"
+// with the referenced row's value for that column, preserving its type.
+func resolveFixtureReferences(data map[string]map[string]fixtureRow) error {
+	for _, rows := range data {
+		for _, fields := range rows {
+			for col, val := range fields {
+				str, ok := val.(string)
+				if !ok {
+					continue
+				}
+				match := fixtureRefRe.FindStringSubmatch(str)
+				if match == nil {
+					continue
+				}
+				resolved, err := lookupFixtureValue(data, match[1], match[2], match[3])
+				if err != nil {
+					return err
+				}
+				fields[col] = resolved
+			}
+		}
+	}
+	return nil
+}
+
+func lookupFixtureValue(data map[string]map[string]fixtureRow, table, row, column string) (any, error) {
+	rows, ok := data[table]
+	if !ok {
+		return nil, fmt.Errorf("fixture reference {{%s.%s.%s}}: no fixture file for table %s", table, row, column, table)
+	}
+	fields, ok := rows[row]
+	if !ok {
+		return nil, fmt.Errorf("fixture reference {{%s.%s.%s}}: no row %q in table %s", table, row, column, row, table)
+	}
+	value, ok := fields[column]
+	if !ok {
+		return nil, fmt.Errorf("fixture reference {{%s.%s.%s}}: no column %q in row %q of table %s", table, row, column, column, row, table)
+	}
+	return value, nil
+}
+
+// newUUIDv4 generates a random (version 4) UUID for the "{{uuid}}" placeholder.
+func newUUIDv4() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
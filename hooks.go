@@ -0,0 +1,122 @@
+package dbro
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Logger is a minimal Printf-style logging interface so callers can wire in
+// zerolog, zap, slog, or any other structured logger without dbro depending
+// on any of them directly.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// MigrationHooks are optional callbacks invoked around migration and
+// flush/drop execution. They let callers report structured, per-statement
+// timing and progress instead of only seeing the final error, which matters
+// for CI pipelines running hundreds of migrations.
+type MigrationHooks struct {
+	// BeforeStatement is called immediately before each statement executes.
+	BeforeStatement func(name, file string, stmtIndex int, statement string)
+	// AfterStatement is called immediately after each statement executes,
+	// whether it succeeded or failed.
+	AfterStatement func(name, file string, stmtIndex int, statement string, duration time.Duration, err error)
+	// BeforeFile is called once before a migration file, or a flush/drop
+	// operation, starts running.
+	BeforeFile func(name, file string)
+	// AfterFile is called once after a migration file, or a flush/drop
+	// operation, finishes running, whether it succeeded or failed.
+	AfterFile func(name, file string, duration time.Duration, err error)
+	// OnError is called once per failure, in addition to AfterStatement/
+	// AfterFile: with the failing statement's index and text when a statement
+	// fails, or with stmtIndex 0 and an empty statement when the file fails
+	// for some other reason (e.g. a commit error).
+	OnError func(name, file string, stmtIndex int, statement string, duration time.Duration, err error)
+}
+
+// SetMigrationHooks installs callbacks invoked around migration statement and
+// file execution.
+func (m *ConnectionManager) SetMigrationHooks(hooks MigrationHooks) {
+	m.hooks = hooks
+}
+
+// SetLogger installs a Printf-style logger used to report migration and
+// flush/drop progress. Pass nil to disable logging.
+func (m *ConnectionManager) SetLogger(logger Logger) {
+	m.logger = logger
+}
+
+// execStatement runs statement against tx, reporting it through any
+// installed MigrationHooks and Logger.
+func (m *ConnectionManager) execStatement(tx *gorm.DB, name, file string, idx int, statement string) error {
+	if m.hooks.BeforeStatement != nil {
+		m.hooks.BeforeStatement(name, file, idx, statement)
+	}
+
+	start := time.Now()
+	err := tx.Exec(statement).Error
+	duration := time.Since(start)
+
+	if m.logger != nil {
+		if err != nil {
+			m.logger.Printf("dbro: %s: %s: statement %d failed after %s: %v", name, file, idx, duration, err)
+		} else {
+			m.logger.Printf("dbro: %s: %s: statement %d applied in %s", name, file, idx, duration)
+		}
+	}
+	if m.hooks.AfterStatement != nil {
+		m.hooks.AfterStatement(name, file, idx, statement, duration, err)
+	}
+	if err != nil && m.hooks.OnError != nil {
+		m.hooks.OnError(name, file, idx, statement, duration, err)
+	}
+	if err != nil {
+		return &statementFailure{err}
+	}
+	return nil
+}
+
+// statementFailure marks an error that execStatement has already reported
+// through MigrationHooks.OnError, so runFileHooked knows not to report it a
+// second time at the file level.
+type statementFailure struct{ err error }
+
+func (e *statementFailure) Error() string { return e.err.Error() }
+func (e *statementFailure) Unwrap() error { return e.err }
+
+// runFileHooked runs fn, a migration file or a flush/drop operation, wrapped
+// in BeforeFile/AfterFile hooks and logger output.
+func (m *ConnectionManager) runFileHooked(name, file string, fn func() error) error {
+	if m.hooks.BeforeFile != nil {
+		m.hooks.BeforeFile(name, file)
+	}
+
+	start := time.Now()
+	err := fn()
+	duration := time.Since(start)
+
+	if m.logger != nil {
+		if err != nil {
+			m.logger.Printf("dbro: %s: %s failed after %s: %v", name, file, duration, err)
+		} else {
+			m.logger.Printf("dbro: %s: %s completed in %s", name, file, duration)
+		}
+	}
+	if m.hooks.AfterFile != nil {
+		m.hooks.AfterFile(name, file, duration, err)
+	}
+	if err != nil && m.hooks.OnError != nil {
+		// Statement-level failures already went through OnError in
+		// execStatement; this only covers file-level failures that never ran
+		// a failing statement (e.g. a commit error, or the MySQL placeholder
+		// row insert in applyMigrationUpMySQL).
+		var sf *statementFailure
+		if !errors.As(err, &sf) {
+			m.hooks.OnError(name, file, 0, "", duration, err)
+		}
+	}
+	return err
+}
@@ -2,7 +2,9 @@ package dbro
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -31,6 +33,9 @@ type ConnectionManager struct {
 	// Migration tracking for RunMigrationOnce
 	executedMigrations map[string]struct{}
 	migrationMu        sync.RWMutex
+	// Observability for migrations and flush/drop, set via SetMigrationHooks/SetLogger
+	hooks  MigrationHooks
+	logger Logger
 }
 
 func (m *ConnectionManager) AddConnectionFunc(driverName string, f connectionFn) {
@@ -134,16 +139,18 @@ func (m *ConnectionManager) FlushAllTables(name string) error {
 		return fmt.Errorf("database connection config not found for %s", name)
 	}
 
-	switch config.DriverName {
-	case DbSqlite, DbLibSQL:
-		return FlushSQLiteTables(db)
-	case DbMySQL:
-		return FlushMySQLTables(db)
-	case DbPostgres:
-		return FlushPostgresTables(db)
-	default:
-		return fmt.Errorf("unsupported database driver: %s", config.DriverName)
-	}
+	return m.runFileHooked(name, "flush", func() error {
+		switch config.DriverName {
+		case DbSqlite, DbLibSQL:
+			return FlushSQLiteTables(db)
+		case DbMySQL:
+			return FlushMySQLTables(db)
+		case DbPostgres:
+			return FlushPostgresTables(db)
+		default:
+			return fmt.Errorf("unsupported database driver: %s", config.DriverName)
+		}
+	})
 }
 
 // DropAllTables drops all tables in the database, ignoring foreign key constraints
@@ -160,57 +167,85 @@ func (m *ConnectionManager) DropAllTables(name string) error {
 		return fmt.Errorf("database connection config not found for %s", name)
 	}
 
-	switch config.DriverName {
-	case DbSqlite, DbLibSQL:
-		return DropSQLiteTables(db)
-	case DbMySQL:
-		return DropMySQLTables(db)
-	case DbPostgres:
-		return DropPostgresTables(db)
-	default:
-		return fmt.Errorf("unsupported database driver: %s", config.DriverName)
-	}
+	return m.runFileHooked(name, "drop", func() error {
+		switch config.DriverName {
+		case DbSqlite, DbLibSQL:
+			return DropSQLiteTables(db)
+		case DbMySQL:
+			return DropMySQLTables(db)
+		case DbPostgres:
+			return DropPostgresTables(db)
+		default:
+			return fmt.Errorf("unsupported database driver: %s", config.DriverName)
+		}
+	})
 }
 
-// RunMigration loads and executes SQL migration file
+// RunMigration loads and executes a single SQL migration file from disk. It
+// does not track which files have been applied; see RunMigrations for
+// versioned, tracked migrations.
 func (m *ConnectionManager) RunMigration(name, filePath string) error {
+	dir, file := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	return m.RunMigrationFS(name, os.DirFS(dir), file)
+}
+
+// RunMigrationFS loads and executes a single SQL migration file from fsys,
+// e.g. one embedded with "//go:embed migrations/*.sql". It does not track
+// which files have been applied; see RunMigrationsFS for versioned, tracked
+// migrations.
+func (m *ConnectionManager) RunMigrationFS(name string, fsys fs.FS, path string) error {
 	// Get database connection
 	db, err := m.GetConnection(name)
 	if err != nil {
 		return fmt.Errorf("failed to get connection: %w", err)
 	}
 
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("database connection config not found for %s", name)
+	}
+
 	// Read SQL file
-	sqlContent, err := os.ReadFile(filePath)
+	sqlContent, err := fs.ReadFile(fsys, path)
 	if err != nil {
-		return fmt.Errorf("failed to read SQL file %s: %w", filePath, err)
+		return fmt.Errorf("failed to read SQL file %s: %w", path, err)
 	}
 
 	// Convert to string and clean up
 	sqlString := strings.TrimSpace(string(sqlContent))
 	if sqlString == "" {
-		return fmt.Errorf("SQL file %s is empty", filePath)
+		return fmt.Errorf("SQL file %s is empty", path)
 	}
 
 	// Split SQL content into individual statements
-	statements := splitSQLStatements(sqlString)
+	statements, err := SplitSQL(sqlString, config.DriverName)
+	if err != nil {
+		return fmt.Errorf("failed to parse SQL file %s: %w", path, err)
+	}
 	if len(statements) == 0 {
-		return fmt.Errorf("no valid SQL statements found in file %s", filePath)
+		return fmt.Errorf("no valid SQL statements found in file %s", path)
 	}
 
 	// Execute statements in a transaction for atomicity
-	return db.Transaction(func(tx *gorm.DB) error {
-		for i, statement := range statements {
-			statement = strings.TrimSpace(statement)
-			if statement == "" {
-				continue // Skip empty statements
-			}
-
-			if err := tx.Exec(statement).Error; err != nil {
-				return fmt.Errorf("failed to execute statement %d in file %s: %w\nStatement: %s", i+1, filePath, err, statement)
+	return m.runFileHooked(name, path, func() error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for i, statement := range statements {
+				statement = strings.TrimSpace(statement)
+				if statement == "" {
+					continue // Skip empty statements
+				}
+
+				if err := m.execStatement(tx, name, path, i+1, statement); err != nil {
+					return fmt.Errorf("failed to execute statement %d in file %s: %w\nStatement: %s", i+1, path, err, statement)
+				}
 			}
-		}
-		return nil
+			return nil
+		})
 	})
 }
 
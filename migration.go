@@ -1,30 +1,157 @@
 package dbro
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 )
 
-// splitSQLStatements splits SQL content into individual statements
-// This handles basic SQL statement separation by semicolons
-func splitSQLStatements(sqlContent string) []string {
+var delimiterDirectiveRe = regexp.MustCompile(`(?i)^DELIMITER[ \t]+(\S+)[ \t]*\r?\n?`)
+
+// SplitSQL splits sql into an ordered list of non-empty statements with their
+// terminators stripped. Unlike a naive split on ";", it understands
+// single-quoted strings (with doubled single-quotes as escapes), double-quoted and backtick
+// identifiers, "--" and "#" line comments, "/* */" block comments, Postgres
+// dollar-quoted blocks ("$tag$ ... $tag$"), and MySQL's "DELIMITER" directive
+// for statements that themselves contain ";" (triggers, stored routines).
+//
+// dialect selects dialect-specific behavior (DbMySQL, DbPostgres, DbSqlite,
+// DbLibSQL); pass "" for dialect-agnostic behavior that enables every rule.
+func SplitSQL(sql string, dialect string) ([]string, error) {
+	mysqlRules := dialect == DbMySQL || dialect == ""
+	postgresRules := dialect == DbPostgres || dialect == ""
+
 	var statements []string
+	var cur strings.Builder
+	delimiter := ";"
+	i, n := 0, len(sql)
+
+	flush := func() {
+		stmt := strings.TrimSpace(cur.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		cur.Reset()
+	}
 
-	// Simple but effective approach
-	parts := strings.Split(sqlContent, ";")
+	for i < n {
+		if mysqlRules && strings.TrimSpace(cur.String()) == "" {
+			if m := delimiterDirectiveRe.FindStringSubmatch(sql[i:]); m != nil {
+				delimiter = m[1]
+				i += len(m[0])
+				continue
+			}
+		}
 
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < n && sql[i+1] == '-':
+			i = skipToLineEnd(sql, i)
+			continue
+		case c == '#' && mysqlRules:
+			i = skipToLineEnd(sql, i)
 			continue
+		case c == '/' && i+1 < n && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated block comment at offset %d", i)
+			}
+			i = i + 2 + end + 2
+			continue
+		case c == '\'':
+			j := skipQuoted(sql, i, '\'')
+			cur.WriteString(sql[i:j])
+			i = j
+			continue
+		case c == '"':
+			j := skipQuoted(sql, i, '"')
+			cur.WriteString(sql[i:j])
+			i = j
+			continue
+		case c == '`':
+			j := skipQuoted(sql, i, '`')
+			cur.WriteString(sql[i:j])
+			i = j
+			continue
+		case c == '$' && postgresRules:
+			if tag, bodyStart, ok := matchDollarTag(sql, i); ok {
+				closeSeq := "$" + tag + "$"
+				closeIdx := strings.Index(sql[bodyStart:], closeSeq)
+				if closeIdx == -1 {
+					return nil, fmt.Errorf("unterminated dollar-quoted block %s at offset %d", closeSeq, i)
+				}
+				blockEnd := bodyStart + closeIdx + len(closeSeq)
+				cur.WriteString(sql[i:blockEnd])
+				i = blockEnd
+				continue
+			}
 		}
 
-		// Skip comments
-		if strings.HasPrefix(part, "--") || strings.HasPrefix(part, "#") {
+		if strings.HasPrefix(sql[i:], delimiter) {
+			flush()
+			i += len(delimiter)
 			continue
 		}
 
-		statements = append(statements, part)
+		cur.WriteByte(c)
+		i++
 	}
+	flush()
 
+	return statements, nil
+}
+
+// skipToLineEnd returns the index of the newline terminating the line
+// starting at i, or len(sql) if the line is unterminated.
+func skipToLineEnd(sql string, i int) int {
+	if end := strings.IndexByte(sql[i:], '\n'); end != -1 {
+		return i + end
+	}
+	return len(sql)
+}
+
+// skipQuoted returns the index just past the string or identifier quoted
+// with quote that starts at i, treating a doubled quote as an escaped quote.
+func skipQuoted(sql string, i int, quote byte) int {
+	j := i + 1
+	n := len(sql)
+	for j < n {
+		if sql[j] == quote {
+			if j+1 < n && sql[j+1] == quote {
+				j += 2
+				continue
+			}
+			return j + 1
+		}
+		j++
+	}
+	return n
+}
+
+// matchDollarTag reports whether sql[i] begins a Postgres dollar-quote tag
+// ("$$" or "$tag$"), returning the tag and the offset where its body starts.
+func matchDollarTag(sql string, i int) (tag string, bodyStart int, ok bool) {
+	j := i + 1
+	n := len(sql)
+	for j < n && sql[j] != '$' && isDollarTagChar(sql[j]) {
+		j++
+	}
+	if j < n && sql[j] == '$' {
+		return sql[i+1 : j], j + 1, true
+	}
+	return "", 0, false
+}
+
+func isDollarTagChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// splitSQLStatements is the dialect-agnostic form of SplitSQL kept from before
+// SplitSQL existed; it returns nil instead of an error for malformed input.
+func splitSQLStatements(sqlContent string) []string {
+	statements, err := SplitSQL(sqlContent, "")
+	if err != nil {
+		return nil
+	}
 	return statements
 }
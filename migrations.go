@@ -0,0 +1,545 @@
+package dbro
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationsTableName is the default table used to track applied migrations.
+const migrationsTableName = "dbro_schema_migrations"
+
+// MigrationOptions configures RunMigrations, MigrateUp, MigrateDown and MigrateTo.
+type MigrationOptions struct {
+	// TableName overrides the default "dbro_schema_migrations" tracking table.
+	TableName string
+}
+
+func (o MigrationOptions) tableName() string {
+	if o.TableName != "" {
+		return o.TableName
+	}
+	return migrationsTableName
+}
+
+// MigrationResult describes a single migration file applied by RunMigrations,
+// MigrateUp, MigrateDown or MigrateTo.
+type MigrationResult struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationStatus describes whether a recorded migration has been applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrationRecord mirrors a row of the dbro_schema_migrations tracking table.
+// Dirty and LastStatement exist because MySQL implicitly commits DDL: a
+// migration recorded as dirty failed partway through and left its statements
+// up to LastStatement applied, with no way to roll them back automatically.
+type migrationRecord struct {
+	Version       int64     `gorm:"column:version"`
+	Name          string    `gorm:"column:name"`
+	Checksum      string    `gorm:"column:checksum"`
+	AppliedAt     time.Time `gorm:"column:applied_at"`
+	Dirty         bool      `gorm:"column:dirty"`
+	LastStatement int       `gorm:"column:last_statement"`
+}
+
+// migrationSet is a single numbered migration discovered on disk, with its
+// up and (optional) down SQL already read into memory.
+type migrationSet struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// discoverMigrations reads dir within fsys for "NNNN_name.up.sql" /
+// "NNNN_name.down.sql" pairs and returns them sorted by numeric version.
+func discoverMigrations(fsys fs.FS, dir string) ([]migrationSet, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	sets := make(map[int64]*migrationSet)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		set, ok := sets[version]
+		if !ok {
+			set = &migrationSet{Version: version, Name: match[2]}
+			sets[version] = set
+		}
+		if match[3] == "up" {
+			set.UpSQL = string(content)
+		} else {
+			set.DownSQL = string(content)
+		}
+	}
+
+	result := make([]migrationSet, 0, len(sets))
+	for _, set := range sets {
+		result = append(result, *set)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func migrationChecksum(upSQL string) string {
+	sum := sha256.Sum256([]byte(upSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureMigrationsTable creates the tracking table if it does not already exist.
+func (m *ConnectionManager) ensureMigrationsTable(db *gorm.DB, table string) error {
+	return db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name TEXT, checksum TEXT, applied_at TIMESTAMP, dirty BOOLEAN, last_statement INT)",
+		table,
+	)).Error
+}
+
+// acquireMigrationLock takes a per-connection lock so that concurrent
+// processes applying migrations against the same database don't race. db
+// must be a single pinned connection obtained via (*gorm.DB).Connection, not
+// a pooled *gorm.DB: GET_LOCK/pg_advisory_lock are scoped to the backend
+// session that issued them, so acquiring, using and releasing the lock must
+// all happen on the same physical connection or the lock never actually
+// guards anything and the release can silently no-op. It returns a release
+// function that must be called on that same connection exactly once,
+// reporting whether the run it guarded succeeded.
+func (m *ConnectionManager) acquireMigrationLock(db *gorm.DB, driver string) (func(success bool) error, error) {
+	switch driver {
+	case DbMySQL:
+		const lockName = "dbro_schema_migrations"
+		// GET_LOCK returns 1 on success, 0 on timeout, NULL on error; none of
+		// those set *gorm.DB.Error, so the result has to be scanned and
+		// checked explicitly or a timeout silently proceeds without the lock.
+		var got sql.NullInt64
+		if err := db.Raw("SELECT GET_LOCK(?, 10)", lockName).Row().Scan(&got); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if !got.Valid || got.Int64 != 1 {
+			return nil, fmt.Errorf("failed to acquire migration lock: GET_LOCK(%s) timed out or failed", lockName)
+		}
+		return func(success bool) error {
+			return db.Exec("SELECT RELEASE_LOCK(?)", lockName).Error
+		}, nil
+	case DbPostgres:
+		const lockKey = 727472 // arbitrary constant identifying dbro migrations
+		if err := db.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func(success bool) error {
+			return db.Exec("SELECT pg_advisory_unlock(?)", lockKey).Error
+		}, nil
+	case DbSqlite, DbLibSQL:
+		// SQLite has no cross-process advisory lock, so BEGIN EXCLUSIVE takes
+		// the database's write lock for the whole run instead, serializing
+		// concurrent writers the same way the other dialects' advisory locks
+		// do. Every migration in the run executes directly against this same
+		// transaction (see applyMigrationUpDirect/MigrateDown); release
+		// commits on success or rolls back the whole run on failure.
+		if err := db.Exec("BEGIN EXCLUSIVE").Error; err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		return func(success bool) error {
+			if success {
+				return db.Exec("COMMIT").Error
+			}
+			return db.Exec("ROLLBACK").Error
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// RunMigrations discovers migration files in dir and applies any that are not
+// yet recorded in the tracking table, returning the migrations that were
+// applied in version order.
+func (m *ConnectionManager) RunMigrations(name, dir string, opts MigrationOptions) ([]MigrationResult, error) {
+	return m.RunMigrationsFS(name, os.DirFS(dir), ".", opts)
+}
+
+// RunMigrationsFS is like RunMigrations but discovers migration files in dir
+// within fsys, e.g. one embedded with "//go:embed migrations/*.sql".
+func (m *ConnectionManager) RunMigrationsFS(name string, fsys fs.FS, dir string, opts MigrationOptions) ([]MigrationResult, error) {
+	return m.applyMigrationsUpTo(name, fsys, dir, opts, -1)
+}
+
+// MigrateUp applies all pending migrations in dir. It is equivalent to RunMigrations.
+func (m *ConnectionManager) MigrateUp(name, dir string, opts MigrationOptions) ([]MigrationResult, error) {
+	return m.RunMigrations(name, dir, opts)
+}
+
+// MigrateTo applies pending migrations in dir up to and including version. It
+// does not revert migrations already applied beyond version; use MigrateDown
+// for that.
+func (m *ConnectionManager) MigrateTo(name, dir string, version int64, opts MigrationOptions) ([]MigrationResult, error) {
+	return m.applyMigrationsUpTo(name, os.DirFS(dir), ".", opts, version)
+}
+
+func (m *ConnectionManager) applyMigrationsUpTo(name string, fsys fs.FS, dir string, opts MigrationOptions, target int64) ([]MigrationResult, error) {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	table := opts.tableName()
+	if err := m.ensureMigrationsTable(db, table); err != nil {
+		return nil, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	sets, err := discoverMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acquiring the lock, checking applied state, and running migrations all
+	// need to happen on the same physical connection (see acquireMigrationLock),
+	// so the whole thing runs inside a single pinned Connection session.
+	var results []MigrationResult
+	err = db.Connection(func(conn *gorm.DB) (runErr error) {
+		release, err := m.acquireMigrationLock(conn, config.DriverName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if relErr := release(runErr == nil); relErr != nil && runErr == nil {
+				runErr = relErr
+			}
+		}()
+
+		var applied []migrationRecord
+		if err := conn.Table(table).Order("version").Find(&applied).Error; err != nil {
+			return fmt.Errorf("failed to read migration state: %w", err)
+		}
+		appliedByVersion := make(map[int64]migrationRecord, len(applied))
+		for _, rec := range applied {
+			appliedByVersion[rec.Version] = rec
+		}
+
+		for _, set := range sets {
+			if target >= 0 && set.Version > target {
+				break
+			}
+			if rec, ok := appliedByVersion[set.Version]; ok {
+				if rec.Dirty {
+					return fmt.Errorf(
+						"migration %d_%s was left partially applied (after statement %d) by a previous run; it needs manual recovery before migrations can continue",
+						set.Version, set.Name, rec.LastStatement,
+					)
+				}
+				checksum := migrationChecksum(set.UpSQL)
+				if rec.Checksum != checksum {
+					return fmt.Errorf(
+						"migration %d_%s has drifted: recorded checksum %s does not match file checksum %s",
+						set.Version, set.Name, rec.Checksum, checksum,
+					)
+				}
+				continue
+			}
+			result, err := m.applyMigrationUp(conn, name, config.DriverName, table, set)
+			if err != nil {
+				return err
+			}
+			results = append(results, *result)
+		}
+		return nil
+	})
+	if err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func (m *ConnectionManager) applyMigrationUp(db *gorm.DB, name, driver, table string, set migrationSet) (*MigrationResult, error) {
+	statements, err := SplitSQL(set.UpSQL, driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse migration %d_%s: %w", set.Version, set.Name, err)
+	}
+	checksum := migrationChecksum(set.UpSQL)
+	file := fmt.Sprintf("%d_%s.up.sql", set.Version, set.Name)
+
+	// MySQL implicitly commits DDL statements, so there's no transaction to
+	// roll back if one fails partway through; it gets its own path that
+	// records progress after every statement instead of only once at the end.
+	if driver == DbMySQL {
+		return m.applyMigrationUpMySQL(db, name, table, set, statements, checksum, file)
+	}
+	// SQLite/libSQL run the whole RunMigrations/MigrateTo call inside a single
+	// BEGIN EXCLUSIVE transaction taken out by acquireMigrationLock, so each
+	// migration has to execute directly against db rather than opening its
+	// own nested transaction.
+	if driver == DbSqlite || driver == DbLibSQL {
+		return m.applyMigrationUpDirect(db, name, table, set, statements, checksum, file)
+	}
+
+	appliedAt := time.Now()
+	record := migrationRecord{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt}
+	run := func(tx *gorm.DB) error {
+		for i, statement := range statements {
+			if err := m.execStatement(tx, name, file, i+1, statement); err != nil {
+				return fmt.Errorf("failed to execute statement %d of migration %d_%s: %w\nStatement: %s",
+					i+1, set.Version, set.Name, err, statement)
+			}
+		}
+		return tx.Table(table).Create(&record).Error
+	}
+
+	err = m.runFileHooked(name, file, func() error {
+		return db.Transaction(run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt}, nil
+}
+
+// applyMigrationUpMySQL runs a migration's statements directly against db
+// (MySQL's implicit DDL commits mean a transaction buys nothing). It records
+// a dirty placeholder row before the first statement and advances
+// last_statement after each one succeeds, so a failure partway through
+// leaves a trace of exactly how far it got instead of silently looking
+// unapplied; the row is marked clean only once every statement has run.
+func (m *ConnectionManager) applyMigrationUpMySQL(db *gorm.DB, name, table string, set migrationSet, statements []string, checksum, file string) (*MigrationResult, error) {
+	appliedAt := time.Now()
+	placeholder := migrationRecord{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt, Dirty: true}
+
+	err := m.runFileHooked(name, file, func() error {
+		if err := db.Table(table).Create(&placeholder).Error; err != nil {
+			return fmt.Errorf("failed to record migration %d_%s as in progress: %w", set.Version, set.Name, err)
+		}
+		for i, statement := range statements {
+			if err := m.execStatement(db, name, file, i+1, statement); err != nil {
+				return fmt.Errorf("failed to execute statement %d of migration %d_%s: %w\nStatement: %s\nmigration left partially applied after statement %d; it needs manual recovery before migrations can continue",
+					i+1, set.Version, set.Name, err, statement, i)
+			}
+			if err := db.Table(table).Where("version = ?", set.Version).Update("last_statement", i+1).Error; err != nil {
+				return fmt.Errorf("failed to record progress for migration %d_%s: %w", set.Version, set.Name, err)
+			}
+		}
+		return db.Table(table).Where("version = ?", set.Version).Update("dirty", false).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt}, nil
+}
+
+// applyMigrationUpDirect runs a migration's statements directly against db,
+// with no transaction of its own, for dialects whose caller already holds an
+// equivalent guarantee externally: SQLite/libSQL run under the single BEGIN
+// EXCLUSIVE transaction acquireMigrationLock takes out for the whole run, so
+// wrapping each migration in its own transaction here would nest and fail.
+func (m *ConnectionManager) applyMigrationUpDirect(db *gorm.DB, name, table string, set migrationSet, statements []string, checksum, file string) (*MigrationResult, error) {
+	appliedAt := time.Now()
+	record := migrationRecord{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt}
+
+	err := m.runFileHooked(name, file, func() error {
+		for i, statement := range statements {
+			if err := m.execStatement(db, name, file, i+1, statement); err != nil {
+				return fmt.Errorf("failed to execute statement %d of migration %d_%s: %w\nStatement: %s",
+					i+1, set.Version, set.Name, err, statement)
+			}
+		}
+		return db.Table(table).Create(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{Version: set.Version, Name: set.Name, Checksum: checksum, AppliedAt: appliedAt}, nil
+}
+
+// MigrateDown reverts the most recently applied migration in dir. It looks up
+// the stored migration name in the tracking table and applies the matching
+// ".down.sql" file. It returns nil, nil if no migrations have been applied.
+func (m *ConnectionManager) MigrateDown(name, dir string, opts MigrationOptions) (*MigrationResult, error) {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	table := opts.tableName()
+	if err := m.ensureMigrationsTable(db, table); err != nil {
+		return nil, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	var down *MigrationResult
+	err = db.Connection(func(conn *gorm.DB) (runErr error) {
+		release, err := m.acquireMigrationLock(conn, config.DriverName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if relErr := release(runErr == nil); relErr != nil && runErr == nil {
+				runErr = relErr
+			}
+		}()
+
+		var last migrationRecord
+		result := conn.Table(table).Order("version DESC").Limit(1).Find(&last)
+		if result.Error != nil {
+			return fmt.Errorf("failed to read migration state: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		sets, err := discoverMigrations(os.DirFS(dir), ".")
+		if err != nil {
+			return err
+		}
+		var target *migrationSet
+		for i := range sets {
+			if sets[i].Version == last.Version {
+				target = &sets[i]
+				break
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("down migration for version %d (%s) not found in %s", last.Version, last.Name, dir)
+		}
+		if target.DownSQL == "" {
+			return fmt.Errorf("migration %d_%s has no .down.sql file", target.Version, target.Name)
+		}
+
+		statements, err := SplitSQL(target.DownSQL, config.DriverName)
+		if err != nil {
+			return fmt.Errorf("failed to parse down migration %d_%s: %w", target.Version, target.Name, err)
+		}
+		file := fmt.Sprintf("%d_%s.down.sql", target.Version, target.Name)
+		run := func(tx *gorm.DB) error {
+			for i, statement := range statements {
+				if err := m.execStatement(tx, name, file, i+1, statement); err != nil {
+					return fmt.Errorf("failed to execute down statement %d of migration %d_%s: %w\nStatement: %s",
+						i+1, target.Version, target.Name, err, statement)
+				}
+			}
+			return tx.Table(table).Delete(&migrationRecord{}, "version = ?", target.Version).Error
+		}
+
+		// MySQL's implicit DDL commits make a transaction pointless, and
+		// SQLite/libSQL are already inside acquireMigrationLock's BEGIN
+		// EXCLUSIVE transaction for this whole call; only Postgres needs its
+		// own transaction here.
+		runDirect := config.DriverName == DbMySQL || config.DriverName == DbSqlite || config.DriverName == DbLibSQL
+		if err := m.runFileHooked(name, file, func() error {
+			if runDirect {
+				return run(conn)
+			}
+			return conn.Transaction(run)
+		}); err != nil {
+			return err
+		}
+
+		down = &MigrationResult{
+			Version:   target.Version,
+			Name:      target.Name,
+			Checksum:  migrationChecksum(target.UpSQL),
+			AppliedAt: time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return down, nil
+}
+
+// MigrationStatus reports, for every migration discovered in dir and every
+// migration recorded in the tracking table, whether it has been applied.
+// Applied is false for migrations found on disk with no tracking row yet, or
+// whose tracking row was left dirty by a failed MySQL migration (see
+// migrationRecord). Results are ordered by version.
+func (m *ConnectionManager) MigrationStatus(name, dir string, opts MigrationOptions) ([]MigrationStatus, error) {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	table := opts.tableName()
+	if err := m.ensureMigrationsTable(db, table); err != nil {
+		return nil, fmt.Errorf("failed to ensure migrations table: %w", err)
+	}
+
+	var records []migrationRecord
+	if err := db.Table(table).Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read migration state: %w", err)
+	}
+	appliedByVersion := make(map[int64]migrationRecord, len(records))
+	for _, rec := range records {
+		appliedByVersion[rec.Version] = rec
+	}
+
+	sets, err := discoverMigrations(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(sets))
+	statuses := make([]MigrationStatus, 0, len(sets))
+	for _, set := range sets {
+		seen[set.Version] = true
+		if rec, ok := appliedByVersion[set.Version]; ok {
+			statuses = append(statuses, MigrationStatus{Version: rec.Version, Name: rec.Name, Applied: !rec.Dirty, AppliedAt: rec.AppliedAt})
+			continue
+		}
+		statuses = append(statuses, MigrationStatus{Version: set.Version, Name: set.Name, Applied: false})
+	}
+	// Tracking rows whose migration file is no longer on disk are still
+	// reported, so a status check never silently drops applied history.
+	for _, rec := range records {
+		if !seen[rec.Version] {
+			statuses = append(statuses, MigrationStatus{Version: rec.Version, Name: rec.Name, Applied: !rec.Dirty, AppliedAt: rec.AppliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
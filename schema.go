@@ -0,0 +1,531 @@
+package dbro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Column describes a single column of an introspected table.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  *string
+}
+
+// Index describes an index on an introspected table.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// ForeignKey describes a single-column foreign key on an introspected table.
+type ForeignKey struct {
+	Column           string
+	ReferencedTable  string
+	ReferencedColumn string
+}
+
+// Table is the introspected structure of a single database table.
+type Table struct {
+	Name        string
+	Columns     []Column
+	PrimaryKey  []string
+	ForeignKeys []ForeignKey
+	Indexes     []Index
+}
+
+// SchemaDump is the introspected structure of every table in a database, as
+// returned by ConnectionManager.DumpSchema.
+type SchemaDump struct {
+	Tables []Table
+}
+
+// ListTables returns the names of the tables in the connection named name.
+func (m *ConnectionManager) ListTables(name string) ([]string, error) {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	var tables []string
+	switch config.DriverName {
+	case DbSqlite, DbLibSQL:
+		err = db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'").Scan(&tables).Error
+	case DbMySQL:
+		err = db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'").Scan(&tables).Error
+	case DbPostgres:
+		err = db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = 'public'").Scan(&tables).Error
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", config.DriverName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// DumpSchema introspects every table in the connection named name, returning
+// their columns, primary keys, foreign keys and indexes.
+func (m *ConnectionManager) DumpSchema(name string) (SchemaDump, error) {
+	db, err := m.GetConnection(name)
+	if err != nil {
+		return SchemaDump{}, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	m.mu.RLock()
+	config, exists := m.connConfigs[name]
+	m.mu.RUnlock()
+	if !exists {
+		return SchemaDump{}, fmt.Errorf("database connection config not found for %s", name)
+	}
+
+	tableNames, err := m.ListTables(name)
+	if err != nil {
+		return SchemaDump{}, err
+	}
+
+	dump := SchemaDump{Tables: make([]Table, 0, len(tableNames))}
+	for _, tableName := range tableNames {
+		var table Table
+		switch config.DriverName {
+		case DbSqlite, DbLibSQL:
+			table, err = dumpSQLiteTable(db, tableName)
+		case DbMySQL:
+			table, err = dumpMySQLTable(db, tableName)
+		case DbPostgres:
+			table, err = dumpPostgresTable(db, tableName)
+		default:
+			return SchemaDump{}, fmt.Errorf("unsupported database driver: %s", config.DriverName)
+		}
+		if err != nil {
+			return SchemaDump{}, fmt.Errorf("failed to introspect table %s: %w", tableName, err)
+		}
+		dump.Tables = append(dump.Tables, table)
+	}
+	return dump, nil
+}
+
+type sqliteColumnInfo struct {
+	Name      string  `gorm:"column:name"`
+	Type      string  `gorm:"column:type"`
+	NotNull   int     `gorm:"column:notnull"`
+	DfltValue *string `gorm:"column:dflt_value"`
+	Pk        int     `gorm:"column:pk"`
+}
+
+type sqliteForeignKeyInfo struct {
+	Table string `gorm:"column:table"`
+	From  string `gorm:"column:from"`
+	To    string `gorm:"column:to"`
+}
+
+type sqliteIndexInfo struct {
+	Name   string `gorm:"column:name"`
+	Unique int    `gorm:"column:unique"`
+}
+
+type sqliteIndexColumnInfo struct {
+	Name string `gorm:"column:name"`
+}
+
+func dumpSQLiteTable(db *gorm.DB, tableName string) (Table, error) {
+	table := Table{Name: tableName}
+
+	var columns []sqliteColumnInfo
+	if err := db.Raw(fmt.Sprintf("PRAGMA table_info(%s)", tableName)).Scan(&columns).Error; err != nil {
+		return Table{}, err
+	}
+	for _, c := range columns {
+		table.Columns = append(table.Columns, Column{
+			Name:     c.Name,
+			Type:     c.Type,
+			Nullable: c.NotNull == 0,
+			Default:  c.DfltValue,
+		})
+		if c.Pk > 0 {
+			table.PrimaryKey = append(table.PrimaryKey, c.Name)
+		}
+	}
+
+	var fks []sqliteForeignKeyInfo
+	if err := db.Raw(fmt.Sprintf("PRAGMA foreign_key_list(%s)", tableName)).Scan(&fks).Error; err != nil {
+		return Table{}, err
+	}
+	for _, fk := range fks {
+		table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+			Column:           fk.From,
+			ReferencedTable:  fk.Table,
+			ReferencedColumn: fk.To,
+		})
+	}
+
+	var indexes []sqliteIndexInfo
+	if err := db.Raw(fmt.Sprintf("PRAGMA index_list(%s)", tableName)).Scan(&indexes).Error; err != nil {
+		return Table{}, err
+	}
+	for _, idx := range indexes {
+		var idxColumns []sqliteIndexColumnInfo
+		if err := db.Raw(fmt.Sprintf("PRAGMA index_info(%s)", idx.Name)).Scan(&idxColumns).Error; err != nil {
+			return Table{}, err
+		}
+		columnNames := make([]string, len(idxColumns))
+		for i, ic := range idxColumns {
+			columnNames[i] = ic.Name
+		}
+		table.Indexes = append(table.Indexes, Index{
+			Name:    idx.Name,
+			Columns: columnNames,
+			Unique:  idx.Unique == 1,
+		})
+	}
+
+	return table, nil
+}
+
+type mysqlColumnRow struct {
+	ColumnName    string  `gorm:"column:COLUMN_NAME"`
+	DataType      string  `gorm:"column:DATA_TYPE"`
+	IsNullable    string  `gorm:"column:IS_NULLABLE"`
+	ColumnDefault *string `gorm:"column:COLUMN_DEFAULT"`
+}
+
+type mysqlForeignKeyRow struct {
+	ColumnName           string `gorm:"column:COLUMN_NAME"`
+	ReferencedTableName  string `gorm:"column:REFERENCED_TABLE_NAME"`
+	ReferencedColumnName string `gorm:"column:REFERENCED_COLUMN_NAME"`
+}
+
+type mysqlIndexRow struct {
+	IndexName  string `gorm:"column:INDEX_NAME"`
+	ColumnName string `gorm:"column:COLUMN_NAME"`
+	NonUnique  int    `gorm:"column:NON_UNIQUE"`
+}
+
+func dumpMySQLTable(db *gorm.DB, tableName string) (Table, error) {
+	table := Table{Name: tableName}
+
+	var columns []mysqlColumnRow
+	if err := db.Raw(
+		"SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT FROM information_schema.columns "+
+			"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ORDINAL_POSITION",
+		tableName,
+	).Scan(&columns).Error; err != nil {
+		return Table{}, err
+	}
+	for _, c := range columns {
+		table.Columns = append(table.Columns, Column{
+			Name:     c.ColumnName,
+			Type:     c.DataType,
+			Nullable: c.IsNullable == "YES",
+			Default:  c.ColumnDefault,
+		})
+	}
+
+	if err := db.Raw(
+		"SELECT COLUMN_NAME FROM information_schema.key_column_usage "+
+			"WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY' ORDER BY ORDINAL_POSITION",
+		tableName,
+	).Scan(&table.PrimaryKey).Error; err != nil {
+		return Table{}, err
+	}
+
+	var fks []mysqlForeignKeyRow
+	if err := db.Raw(
+		"SELECT COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.key_column_usage "+
+			"WHERE table_schema = DATABASE() AND table_name = ? AND REFERENCED_TABLE_NAME IS NOT NULL",
+		tableName,
+	).Scan(&fks).Error; err != nil {
+		return Table{}, err
+	}
+	for _, fk := range fks {
+		table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+			Column:           fk.ColumnName,
+			ReferencedTable:  fk.ReferencedTableName,
+			ReferencedColumn: fk.ReferencedColumnName,
+		})
+	}
+
+	var stats []mysqlIndexRow
+	if err := db.Raw(
+		"SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE FROM information_schema.statistics "+
+			"WHERE table_schema = DATABASE() AND table_name = ? AND INDEX_NAME != 'PRIMARY' ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+		tableName,
+	).Scan(&stats).Error; err != nil {
+		return Table{}, err
+	}
+	table.Indexes = groupIndexRows(stats, func(r mysqlIndexRow) (string, string, bool) {
+		return r.IndexName, r.ColumnName, r.NonUnique == 0
+	})
+
+	return table, nil
+}
+
+type postgresColumnRow struct {
+	ColumnName    string  `gorm:"column:column_name"`
+	DataType      string  `gorm:"column:data_type"`
+	IsNullable    string  `gorm:"column:is_nullable"`
+	ColumnDefault *string `gorm:"column:column_default"`
+}
+
+type postgresForeignKeyRow struct {
+	ColumnName           string `gorm:"column:column_name"`
+	ReferencedTableName  string `gorm:"column:referenced_table"`
+	ReferencedColumnName string `gorm:"column:referenced_column"`
+}
+
+type postgresIndexRow struct {
+	IndexName  string `gorm:"column:index_name"`
+	ColumnName string `gorm:"column:column_name"`
+	IsUnique   bool   `gorm:"column:is_unique"`
+}
+
+func dumpPostgresTable(db *gorm.DB, tableName string) (Table, error) {
+	table := Table{Name: tableName}
+
+	var columns []postgresColumnRow
+	if err := db.Raw(
+		"SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns "+
+			"WHERE table_schema = 'public' AND table_name = ? ORDER BY ordinal_position",
+		tableName,
+	).Scan(&columns).Error; err != nil {
+		return Table{}, err
+	}
+	for _, c := range columns {
+		table.Columns = append(table.Columns, Column{
+			Name:     c.ColumnName,
+			Type:     c.DataType,
+			Nullable: c.IsNullable == "YES",
+			Default:  c.ColumnDefault,
+		})
+	}
+
+	if err := db.Raw(
+		"SELECT a.attname FROM pg_index i "+
+			"JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) "+
+			"WHERE i.indrelid = ?::regclass AND i.indisprimary",
+		tableName,
+	).Scan(&table.PrimaryKey).Error; err != nil {
+		return Table{}, err
+	}
+
+	var fks []postgresForeignKeyRow
+	if err := db.Raw(
+		"SELECT kcu.column_name, ccu.table_name AS referenced_table, ccu.column_name AS referenced_column "+
+			"FROM information_schema.table_constraints tc "+
+			"JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema "+
+			"JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema "+
+			"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = 'public' AND tc.table_name = ?",
+		tableName,
+	).Scan(&fks).Error; err != nil {
+		return Table{}, err
+	}
+	for _, fk := range fks {
+		table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+			Column:           fk.ColumnName,
+			ReferencedTable:  fk.ReferencedTableName,
+			ReferencedColumn: fk.ReferencedColumnName,
+		})
+	}
+
+	var indexRows []postgresIndexRow
+	if err := db.Raw(
+		"SELECT ix.relname AS index_name, a.attname AS column_name, idx.indisunique AS is_unique "+
+			"FROM pg_class t "+
+			"JOIN pg_index idx ON t.oid = idx.indrelid "+
+			"JOIN pg_class ix ON ix.oid = idx.indexrelid "+
+			"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(idx.indkey) "+
+			"WHERE t.relkind = 'r' AND t.relname = ? AND NOT idx.indisprimary "+
+			"ORDER BY ix.relname",
+		tableName,
+	).Scan(&indexRows).Error; err != nil {
+		return Table{}, err
+	}
+	table.Indexes = groupIndexRows(indexRows, func(r postgresIndexRow) (string, string, bool) {
+		return r.IndexName, r.ColumnName, r.IsUnique
+	})
+
+	return table, nil
+}
+
+// groupIndexRows folds a flat (index, column) result set into Index values,
+// preserving the order indexes were first seen in rows.
+func groupIndexRows[T any](rows []T, fields func(T) (indexName, columnName string, unique bool)) []Index {
+	var order []string
+	byName := make(map[string]*Index)
+	for _, row := range rows {
+		indexName, columnName, unique := fields(row)
+		idx, ok := byName[indexName]
+		if !ok {
+			idx = &Index{Name: indexName, Unique: unique}
+			byName[indexName] = idx
+			order = append(order, indexName)
+		}
+		idx.Columns = append(idx.Columns, columnName)
+	}
+
+	indexes := make([]Index, 0, len(order))
+	for _, name := range order {
+		indexes = append(indexes, *byName[name])
+	}
+	return indexes
+}
+
+// ToSQL renders the schema as portable "CREATE TABLE"/"CREATE INDEX"
+// statements. It is meant for review and diffing, not as a drop-in
+// replacement for a dialect's own DDL.
+func (s SchemaDump) ToSQL() string {
+	var b strings.Builder
+	for _, table := range s.Tables {
+		fmt.Fprintf(&b, "CREATE TABLE %s (\n", table.Name)
+
+		lines := make([]string, 0, len(table.Columns)+1+len(table.ForeignKeys))
+		for _, col := range table.Columns {
+			line := fmt.Sprintf("  %s %s", col.Name, col.Type)
+			if !col.Nullable {
+				line += " NOT NULL"
+			}
+			if col.Default != nil {
+				line += fmt.Sprintf(" DEFAULT %s", *col.Default)
+			}
+			lines = append(lines, line)
+		}
+		if len(table.PrimaryKey) > 0 {
+			lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(table.PrimaryKey, ", ")))
+		}
+		for _, fk := range table.ForeignKeys {
+			lines = append(lines, fmt.Sprintf("  FOREIGN KEY (%s) REFERENCES %s(%s)", fk.Column, fk.ReferencedTable, fk.ReferencedColumn))
+		}
+
+		b.WriteString(strings.Join(lines, ",\n"))
+		b.WriteString("\n);\n")
+
+		for _, idx := range table.Indexes {
+			unique := ""
+			if idx.Unique {
+				unique = "UNIQUE "
+			}
+			fmt.Fprintf(&b, "CREATE %sINDEX %s ON %s(%s);\n", unique, idx.Name, table.Name, strings.Join(idx.Columns, ", "))
+		}
+	}
+	return b.String()
+}
+
+// SchemaChangeKind identifies the kind of difference a SchemaChange reports.
+type SchemaChangeKind string
+
+const (
+	SchemaChangeTableAdded    SchemaChangeKind = "table_added"
+	SchemaChangeTableRemoved  SchemaChangeKind = "table_removed"
+	SchemaChangeColumnAdded   SchemaChangeKind = "column_added"
+	SchemaChangeColumnRemoved SchemaChangeKind = "column_removed"
+	SchemaChangeColumnChanged SchemaChangeKind = "column_changed"
+)
+
+// SchemaChange describes a single difference found by SchemaDump.Diff.
+type SchemaChange struct {
+	Kind   SchemaChangeKind
+	Table  string
+	Column string
+	Detail string
+}
+
+// Diff compares s against other and returns the changes needed to turn s into
+// other: tables or columns present in other but not s are reported as added,
+// those present in s but not other as removed, and columns present in both
+// with a different type or nullability as changed. This is useful for
+// verifying that migrations applied to one database (e.g. dev) match another
+// (e.g. production).
+func (s SchemaDump) Diff(other SchemaDump) []SchemaChange {
+	var changes []SchemaChange
+
+	sTables := tablesByName(s.Tables)
+	oTables := tablesByName(other.Tables)
+
+	for tableName, oTable := range oTables {
+		sTable, ok := sTables[tableName]
+		if !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeTableAdded, Table: tableName})
+			continue
+		}
+		changes = append(changes, diffColumns(tableName, sTable, oTable)...)
+	}
+	for tableName := range sTables {
+		if _, ok := oTables[tableName]; !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeTableRemoved, Table: tableName})
+		}
+	}
+
+	// Map iteration order is random; sort so two Diff calls over the same
+	// inputs always return changes in the same order.
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Table != changes[j].Table {
+			return changes[i].Table < changes[j].Table
+		}
+		if changes[i].Column != changes[j].Column {
+			return changes[i].Column < changes[j].Column
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+
+	return changes
+}
+
+func tablesByName(tables []Table) map[string]Table {
+	byName := make(map[string]Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	return byName
+}
+
+func diffColumns(tableName string, sTable, oTable Table) []SchemaChange {
+	var changes []SchemaChange
+
+	sCols := columnsByName(sTable.Columns)
+	oCols := columnsByName(oTable.Columns)
+
+	for colName, oCol := range oCols {
+		sCol, ok := sCols[colName]
+		if !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeColumnAdded, Table: tableName, Column: colName})
+			continue
+		}
+		if sCol.Type != oCol.Type || sCol.Nullable != oCol.Nullable {
+			changes = append(changes, SchemaChange{
+				Kind:   SchemaChangeColumnChanged,
+				Table:  tableName,
+				Column: colName,
+				Detail: fmt.Sprintf("type %s/nullable=%t -> type %s/nullable=%t", sCol.Type, sCol.Nullable, oCol.Type, oCol.Nullable),
+			})
+		}
+	}
+	for colName := range sCols {
+		if _, ok := oCols[colName]; !ok {
+			changes = append(changes, SchemaChange{Kind: SchemaChangeColumnRemoved, Table: tableName, Column: colName})
+		}
+	}
+
+	return changes
+}
+
+func columnsByName(columns []Column) map[string]Column {
+	byName := make(map[string]Column, len(columns))
+	for _, c := range columns {
+		byName[c.Name] = c
+	}
+	return byName
+}